@@ -0,0 +1,66 @@
+package render
+
+import (
+	"fmt"
+	"github.com/eknkc/amber"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// AmberExtension is the file extension AmberEngine compiles.
+const AmberExtension = ".amber"
+
+// AmberEngine is a TemplateEngine that pre-compiles Amber (github.com/eknkc/amber) templates to
+// *template.Template at startup.
+type AmberEngine struct {
+	// Options are passed to the Amber compiler for every template.
+	Options amber.Options
+}
+
+func (e *AmberEngine) Compile(dir, ext string) (map[string]Renderable, error) {
+	result := make(map[string]Renderable)
+
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		if filepath.Ext(path) != AmberExtension {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(rel[0 : len(rel)-len(AmberExtension)])
+
+		compiler := amber.New()
+		compiler.Options = e.Options
+		if err := compiler.ParseFile(path); err != nil {
+			return err
+		}
+
+		tmpl, err := compiler.Compile()
+		if err != nil {
+			return err
+		}
+
+		result[name] = tmpl
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (e *AmberEngine) Execute(name string, render Renderable, data interface{}, w io.Writer) error {
+	tmpl, ok := render.(*template.Template)
+	if !ok {
+		return fmt.Errorf("render: unexpected renderable type %T for %q", render, name)
+	}
+	return tmpl.Execute(w, data)
+}
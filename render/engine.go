@@ -0,0 +1,285 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Renderable is a single template compiled by a TemplateEngine. Its concrete type is owned by
+// whichever engine produced it (e.g. *template.Template for the default html engine) -- only
+// that engine's Execute ever needs to look inside it.
+type Renderable interface{}
+
+// TemplateEngine compiles a directory of source templates with a given extension into named
+// Renderables, and later executes one of them by name. Renderer dispatches HTML calls to
+// whichever engine compiled the requested template name, so a single Directory can mix template
+// languages (e.g. &HTMLEngine{} alongside Amber or Markdown, included explicitly in
+// RenderConfig.Engines).
+type TemplateEngine interface {
+	Compile(dir, ext string) (map[string]Renderable, error)
+	Execute(name string, render Renderable, data interface{}, w io.Writer) error
+}
+
+// compiledTemplate pairs a Renderable with the engine that produced it, so renderer.HTML can
+// dispatch a template name to the Execute that knows how to run it.
+type compiledTemplate struct {
+	engine TemplateEngine
+	render Renderable
+}
+
+// compile runs every configured engine (a single HTMLEngine built from cfg if Engines is empty)
+// over cfg.Directory and merges the results into a single name -> compiledTemplate registry. It
+// is an error for two engines to register the same template name.
+func compile(cfg RenderConfig) map[string]compiledTemplate {
+	engines := cfg.Engines
+	if len(engines) == 0 {
+		engines = []TemplateEngine{newHTMLEngine(cfg)}
+	}
+
+	registry := make(map[string]compiledTemplate)
+	for _, engine := range engines {
+		compiled, err := engine.Compile(cfg.Directory, cfg.Extension)
+		if err != nil {
+			panic(err)
+		}
+
+		for name, render := range compiled {
+			if _, exists := registry[name]; exists {
+				panic(fmt.Sprintf("render: template name %q is registered by more than one engine", name))
+			}
+			registry[name] = compiledTemplate{engine: engine, render: render}
+		}
+	}
+
+	return registry
+}
+
+func errUnknownTemplate(name string) error {
+	return fmt.Errorf("render: template %q is not registered", name)
+}
+
+// builtinFuncs are placeholder definitions so that templates referencing {{yield}}, {{partial}},
+// and {{current}} parse successfully at compile time. HTMLEngine.Execute rebinds them per-request
+// to the real, request-scoped implementations via Template.Funcs before execution.
+var builtinFuncs = template.FuncMap{
+	"yield":   func() (template.HTML, error) { return "", nil },
+	"partial": func(string) (template.HTML, error) { return "", nil },
+	"current": func() string { return "" },
+}
+
+// HTMLEngine is the default TemplateEngine, backed by html/template. It compiles every matching
+// file under Directory into a single template tree registered by slash-separated relative name
+// (e.g. "users/index", "partials/sidebar", "layout"), so templates can reference each other with
+// {{template ...}} and the layout's {{yield}}/{{partial}} helpers can execute any of them by name.
+// Renderer uses an HTMLEngine built from RenderConfig when Engines is empty; include one
+// explicitly in Engines (e.g. &HTMLEngine{Layout: cfg.Layout}) to mix it with other engines.
+type HTMLEngine struct {
+	// Layout, when set, is the template executed for every HTML call handled by this engine. See
+	// RenderConfig.Layout.
+	Layout string
+	// Funcs are merged into the built-in yield/partial/current helpers and made available to
+	// every template this engine compiles. See RenderConfig.Funcs.
+	Funcs template.FuncMap
+	// AssetFn and AssetNames, when both set, load templates from bundled assets instead of the
+	// filesystem. See RenderConfig.AssetFn and RenderConfig.AssetNames.
+	AssetFn    func(name string) ([]byte, error)
+	AssetNames func() []string
+}
+
+func newHTMLEngine(cfg RenderConfig) *HTMLEngine {
+	return &HTMLEngine{
+		Layout:     cfg.Layout,
+		Funcs:      cfg.Funcs,
+		AssetFn:    cfg.AssetFn,
+		AssetNames: cfg.AssetNames,
+	}
+}
+
+func (e *HTMLEngine) Compile(dir, ext string) (map[string]Renderable, error) {
+	t := template.New("").Funcs(builtinFuncs)
+	if e.Funcs != nil {
+		t = t.Funcs(e.Funcs)
+	}
+
+	var names []string
+	var err error
+	if e.AssetFn != nil && e.AssetNames != nil {
+		names, err = e.compileAssets(t, dir, ext)
+	} else {
+		names, err = e.compileFiles(t, dir, ext)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]Renderable, len(names))
+	for _, name := range names {
+		result[name] = t
+	}
+	return result, nil
+}
+
+// compileFiles walks dir on the filesystem, parsing every file with the given extension into t.
+// The layout itself (if any) is parsed so {{yield}} can address it, but is never added to names,
+// so it can't be rendered as if it were an ordinary page -- doing so would feed {{yield}} back
+// into the layout itself and recurse forever.
+func (e *HTMLEngine) compileFiles(t *template.Template, dir, ext string) ([]string, error) {
+	var names []string
+
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if filepath.Ext(rel) != ext {
+			return nil
+		}
+		name := filepath.ToSlash(rel[0 : len(rel)-len(ext)])
+
+		buf, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		if _, err := t.New(name).Parse(string(buf)); err != nil {
+			return err
+		}
+
+		if name != e.Layout {
+			names = append(names, name)
+		}
+		return nil
+	})
+
+	return names, err
+}
+
+// compileAssets loads templates from assetNames/assetFn instead of the filesystem, for binaries
+// that bundle their templates (e.g. via go-bindata or an embed.FS-backed reader). Asset names are
+// matched the same way as filesystem paths: filtered to those under dir with the given extension,
+// then named by their path relative to dir with the extension stripped. As in compileFiles, the
+// layout is parsed but excluded from names.
+func (e *HTMLEngine) compileAssets(t *template.Template, dir, ext string) ([]string, error) {
+	prefix := filepath.ToSlash(dir)
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+
+	var names []string
+	for _, asset := range e.AssetNames() {
+		asset = filepath.ToSlash(asset)
+		if !strings.HasPrefix(asset, prefix) || filepath.Ext(asset) != ext {
+			continue
+		}
+		name := asset[len(prefix) : len(asset)-len(ext)]
+
+		buf, err := e.AssetFn(asset)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := t.New(name).Parse(string(buf)); err != nil {
+			return nil, err
+		}
+
+		if name != e.Layout {
+			names = append(names, name)
+		}
+	}
+
+	return names, nil
+}
+
+func (e *HTMLEngine) Execute(name string, render Renderable, data interface{}, w io.Writer) error {
+	if name == e.Layout {
+		return fmt.Errorf("render: %q is the layout and cannot be rendered as a page", name)
+	}
+
+	tree, ok := render.(*template.Template)
+	if !ok {
+		return fmt.Errorf("render: unexpected renderable type %T for %q", render, name)
+	}
+
+	// If a layout is being used, we want to execute the layout template, which uses {{yield}}
+	// to pull in the template named by name.
+	entry := name
+	if e.Layout != "" {
+		entry = e.Layout
+	}
+
+	// Clone the compiled tree before executing it so that this request can never race with
+	// another in-flight request sharing the same *template.Template, and so the yield/partial/
+	// current helpers below can be bound to this request's name and data alone.
+	t, err := tree.Clone()
+	if err != nil {
+		return err
+	}
+
+	current := entry
+	yielding := false
+	t = t.Funcs(template.FuncMap{
+		// yield leaves *current pointing at the yielded page for the rest of the layout's
+		// render, so {{current}} reflects the page rather than reverting to the layout's own
+		// name once yield returns. yielding guards against name itself (or anything it
+		// includes) calling {{yield}} again, which would otherwise recurse into name forever --
+		// yield is only meaningful once, for the layout pulling in its page.
+		"yield": func() (template.HTML, error) {
+			if yielding {
+				return "", fmt.Errorf("render: %q calls yield, but yield may only be used by the layout", name)
+			}
+			yielding = true
+			defer func() { yielding = false }()
+			return executeNamed(t, name, data, &current, true)
+		},
+		"partial": func(partialName string) (template.HTML, error) {
+			full := "partials/" + partialName
+			if t.Lookup(full) == nil {
+				return "", nil
+			}
+			return executeNamed(t, full, data, &current, false)
+		},
+		"current": func() string {
+			return current
+		},
+	})
+
+	html, err := executeNamed(t, entry, data, &current, false)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(w, string(html))
+	return err
+}
+
+// executeNamed renders the named template from t into a pooled buffer, temporarily pointing
+// *current at name so the {{current}} helper reflects whichever template is actually rendering.
+// If sticky is true, *current is left at name once the render completes instead of being
+// restored to its previous value -- used by yield so {{current}} keeps reporting the yielded
+// page for the remainder of the layout's render, rather than reverting to the layout's own name.
+func executeNamed(t *template.Template, name string, data interface{}, current *string, sticky bool) (template.HTML, error) {
+	prev := *current
+	*current = name
+	if !sticky {
+		defer func() { *current = prev }()
+	}
+
+	buf := bufPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	defer bufPool.Put(buf)
+
+	if err := t.ExecuteTemplate(buf, name, data); err != nil {
+		return "", err
+	}
+	return template.HTML(buf.String()), nil
+}
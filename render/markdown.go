@@ -0,0 +1,72 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/russross/blackfriday"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"text/template"
+)
+
+// MarkdownExtension is the file extension MarkdownEngine compiles.
+const MarkdownExtension = ".md"
+
+// MarkdownEngine is a TemplateEngine that renders .md files to HTML. Each file is first executed
+// as a text/template (so {{.Field}} substitution works against the HTML call's data) and the
+// result is then converted to HTML with blackfriday.
+type MarkdownEngine struct{}
+
+func (e *MarkdownEngine) Compile(dir, ext string) (map[string]Renderable, error) {
+	result := make(map[string]Renderable)
+
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		if filepath.Ext(path) != MarkdownExtension {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(rel[0 : len(rel)-len(MarkdownExtension)])
+
+		buf, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		tmpl, err := template.New(name).Parse(string(buf))
+		if err != nil {
+			return err
+		}
+
+		result[name] = tmpl
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (e *MarkdownEngine) Execute(name string, render Renderable, data interface{}, w io.Writer) error {
+	tmpl, ok := render.(*template.Template)
+	if !ok {
+		return fmt.Errorf("render: unexpected renderable type %T for %q", render, name)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return err
+	}
+
+	_, err := w.Write(blackfriday.MarkdownCommon(buf.Bytes()))
+	return err
+}
@@ -26,20 +26,41 @@ package render
 import (
 	"bytes"
 	"encoding/json"
+	"encoding/xml"
 	"github.com/codegangsta/martini"
 	"html/template"
-	"io/ioutil"
 	"net/http"
-	"os"
-	"path/filepath"
+	"regexp"
+	"sync"
+	"sync/atomic"
 )
 
 const (
-	ContentType = "Content-Type"
-	ContentJSON = "application/json"
-	ContentHTML = "text/html"
+	ContentType   = "Content-Type"
+	ContentJSON   = "application/json"
+	ContentHTML   = "text/html"
+	ContentXML    = "text/xml"
+	ContentText   = "text/plain"
+	ContentBinary = "application/octet-stream"
+	ContentJSONP  = "application/javascript"
+
+	defaultCharset = "UTF-8"
 )
 
+// jsonpCallback restricts JSONP callback names to a safe subset of valid JS identifier
+// characters, so a callback taken from a query parameter can't break out of the
+// application/javascript response it's reflected into.
+var jsonpCallback = regexp.MustCompile(`^[\w$.\[\]]+$`)
+
+// bufPool holds reusable buffers that templates are executed into before anything is written
+// to the http.ResponseWriter, so a mid-render error never leaves a response with headers already
+// sent but a truncated body.
+var bufPool = sync.Pool{
+	New: func() interface{} {
+		return new(bytes.Buffer)
+	},
+}
+
 // Render is a service that can be injected into a Martini handler. Render provides functions for easily writing JSON and
 // HTML templates out to a http Response.
 type Render interface {
@@ -47,6 +68,15 @@ type Render interface {
 	JSON(status int, v interface{})
 	// HTML renders a html template specified by the name and writes the result and given status to the http.ResponseWriter.
 	HTML(status int, name string, v interface{})
+	// XML writes the given status and XML serialized version of the given value to the http.ResponseWriter.
+	XML(status int, v interface{})
+	// Text writes the given status and a plain text string to the http.ResponseWriter.
+	Text(status int, v string)
+	// Data writes the given status and raw bytes to the http.ResponseWriter, with no Content-Type charset applied.
+	Data(status int, v []byte)
+	// JSONP writes the given status and JSON serialized version of the given value, wrapped in the named
+	// JavaScript callback, to the http.ResponseWriter.
+	JSONP(status int, callback string, v interface{})
 	// Error is a convenience function that writes an http status to the http.ResponseWriter.
 	Error(status int)
 }
@@ -54,112 +84,191 @@ type Render interface {
 type RenderConfig struct {
 	Directory string
 	Extension string
+	// Layout, when set, is the template executed for every HTML call. The layout uses {{yield}}
+	// to render the template named in HTML, {{partial "name"}} to render partials/name, and
+	// {{current}} to get the name of whichever template is presently executing. Only honored by
+	// the default html engine.
 	Layout string
+	// Funcs are merged into the built-in yield/partial/current helpers and made available to
+	// every template compiled from Directory by the default html engine.
+	Funcs template.FuncMap
+	// Engines, when set, replaces the default html/template engine with one or more
+	// TemplateEngine adapters, letting Directory mix template languages (e.g. Amber or
+	// Markdown alongside or instead of html/template).
+	Engines []TemplateEngine
+	// Charset is appended to the Content-Type header of text responses (JSON, HTML, XML, Text, JSONP).
+	// Defaults to "UTF-8" when empty.
+	Charset string
+	// IndentJSON, when true, marshals JSON with a two-space indent instead of the compact form.
+	IndentJSON bool
+	// JSONPrefix is written before the JSON payload, e.g. ")]}',\n" or "while(1);" to defend
+	// against JSON hijacking in older browsers. Ignored when empty.
+	JSONPrefix string
+	// StreamJSON, when true, encodes directly to the http.ResponseWriter with json.Encoder instead
+	// of marshalling the whole payload into memory first. Trades the ability to recover from a
+	// marshal error after headers are sent for lower memory use on large payloads.
+	StreamJSON bool
+	// HTMLEscape enables HTML-escaping (<, >, & as unicode escapes) in the StreamJSON encoder.
+	// Most API responses don't want this, so it defaults to false.
+	HTMLEscape bool
+	// AssetFn and AssetNames let the default html engine load templates bundled into the binary
+	// (e.g. via go-bindata) instead of walking Directory on the filesystem. Both must be set for
+	// either to take effect; AssetNames lists every bundled asset, and AssetFn reads one by the
+	// same name.
+	AssetFn    func(name string) ([]byte, error)
+	AssetNames func() []string
 }
 
 // Renderer is a Middleware that maps a render.Render service into the Martini handler chain. Renderer will compile templates
-// globbed in the given dir. Templates must have the .tmpl extension to be compiled.
+// globbed in the given dir using the configured TemplateEngines (the default html/template engine if none are configured).
+// The default engine compiles only templates whose extension matches cfg.Extension.
 //
 // If MARTINI_ENV is set to "" or "development" then templates will be recompiled on every request. For more performance, set the
-// MARTINI_ENV environment variable to "production"
+// MARTINI_ENV environment variable to "production" to compile once at startup.
 func Renderer(cfg RenderConfig) martini.Handler {
-	t := compile(cfg)
+	var t atomic.Value
+	t.Store(compile(cfg))
+	ctypes := newContentTypes(cfg.Charset)
 
 	return func(res http.ResponseWriter, c martini.Context) {
-		// recompile for easy development
+		// recompile for easy development. Stored in an atomic.Value rather than a plain variable
+		// because this recompile-and-read happens unsynchronized across concurrent requests.
 		if martini.Env == martini.Dev {
-			t = compile(cfg)
+			t.Store(compile(cfg))
 		}
-		c.MapTo(&renderer{res, cfg, t}, (*Render)(nil))
+		c.MapTo(&renderer{res, cfg, t.Load().(map[string]compiledTemplate), ctypes}, (*Render)(nil))
 	}
 }
 
-func compile(cfg RenderConfig) map[string]*template.Template {
-	tmplMap := make(map[string]*template.Template)
-
-	filepath.Walk(cfg.Directory, func(path string, info os.FileInfo, err error) error {
-		r, err := filepath.Rel(cfg.Directory, path)
-		if err != nil {
-			return err
-		}
-
-		ext := filepath.Ext(r)
-		name := (r[0 : len(r)-len(ext)])
-		if ext == cfg.Extension {
-			if name == cfg.Layout {
-				// We don't parse the layout file
-				return nil
-			}
-
-			t := template.New(name)
-
-			buf, err := ioutil.ReadFile(path)
-			if err != nil {
-				panic(err)
-			}
-
-			tmpl := t.New(filepath.ToSlash(name))
-
-			// Bomb out if parse fails. We don't want any silent server starts.
-			if cfg.Layout == "" {
-				// If a layout isn't specified, parse as normal
-				template.Must(tmpl.Parse(string(buf)))
-			} else {
-				// If we do have a layout specified, include that in the parse
-				template.Must(tmpl.ParseFiles(filepath.Join(cfg.Directory, cfg.Layout + cfg.Extension), path))
-			}
-
-			// XXX In production this should only run once, but in development this is run
-			// with every request. Should we lock before adding the template to the map?
-			tmplMap[name] = t
-		}
-
-		return nil
-	})
+// contentTypes holds the Content-Type header values for a Renderer, with the configured charset
+// (or defaultCharset) already appended. Building these once at Renderer setup, rather than on
+// every request, avoids a string concatenation per response.
+type contentTypes struct {
+	json, html, xml, text, jsonp string
+}
 
-	return tmplMap
+func newContentTypes(charset string) contentTypes {
+	if charset == "" {
+		charset = defaultCharset
+	}
+	suffix := "; charset=" + charset
+	return contentTypes{
+		json:  ContentJSON + suffix,
+		html:  ContentHTML + suffix,
+		xml:   ContentXML + suffix,
+		text:  ContentText + suffix,
+		jsonp: ContentJSONP + suffix,
+	}
 }
 
 type renderer struct {
 	http.ResponseWriter
-	cfg RenderConfig
-	t map[string]*template.Template
+	cfg    RenderConfig
+	t      map[string]compiledTemplate
+	ctypes contentTypes
 }
 
 func (r *renderer) JSON(status int, v interface{}) {
-	result, err := json.Marshal(v)
+	r.Header().Set(ContentType, r.ctypes.json)
+
+	if r.cfg.StreamJSON {
+		r.WriteHeader(status)
+		if r.cfg.JSONPrefix != "" {
+			r.Write([]byte(r.cfg.JSONPrefix))
+		}
+
+		enc := json.NewEncoder(r)
+		enc.SetEscapeHTML(r.cfg.HTMLEscape)
+		if r.cfg.IndentJSON {
+			enc.SetIndent("", "  ")
+		}
+		enc.Encode(v)
+		return
+	}
+
+	var result []byte
+	var err error
+	if r.cfg.IndentJSON {
+		result, err = json.MarshalIndent(v, "", "  ")
+	} else {
+		result, err = json.Marshal(v)
+	}
 	if err != nil {
 		http.Error(r, err.Error(), 500)
 		return
 	}
 
 	// json rendered fine, write out the result
-	r.Header().Set(ContentType, ContentJSON)
 	r.WriteHeader(status)
+	if r.cfg.JSONPrefix != "" {
+		r.Write([]byte(r.cfg.JSONPrefix))
+	}
 	r.Write(result)
 }
 
 func (r *renderer) HTML(status int, name string, binding interface{}) {
-	var buf bytes.Buffer
-	var tmpl string
-
-	// If a layout is being used, we want to execute the layout template
-	// which will pull in the other templates compiled into this object
-	if r.cfg.Layout != "" {
-		tmpl = r.cfg.Layout
-	} else {
-		tmpl = name
+	entry, ok := r.t[name]
+	if !ok {
+		http.Error(r, errUnknownTemplate(name).Error(), 500)
+		return
 	}
 
-	if err := r.t[name].ExecuteTemplate(&buf, tmpl, binding); err != nil {
+	buf := bufPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	defer bufPool.Put(buf)
+
+	if err := entry.engine.Execute(name, entry.render, binding, buf); err != nil {
 		http.Error(r, err.Error(), 500)
 		return
 	}
 
 	// template rendered fine, write out the result
-	r.Header().Set(ContentType, ContentHTML)
+	r.Header().Set(ContentType, r.ctypes.html)
 	r.WriteHeader(status)
-	r.Write(buf.Bytes())
+	buf.WriteTo(r)
+}
+
+func (r *renderer) XML(status int, v interface{}) {
+	result, err := xml.Marshal(v)
+	if err != nil {
+		http.Error(r, err.Error(), 500)
+		return
+	}
+
+	r.Header().Set(ContentType, r.ctypes.xml)
+	r.WriteHeader(status)
+	r.Write(result)
+}
+
+func (r *renderer) Text(status int, v string) {
+	r.Header().Set(ContentType, r.ctypes.text)
+	r.WriteHeader(status)
+	r.Write([]byte(v))
+}
+
+func (r *renderer) Data(status int, v []byte) {
+	r.Header().Set(ContentType, ContentBinary)
+	r.WriteHeader(status)
+	r.Write(v)
+}
+
+func (r *renderer) JSONP(status int, callback string, v interface{}) {
+	if !jsonpCallback.MatchString(callback) {
+		http.Error(r, "render: invalid JSONP callback name", 400)
+		return
+	}
+
+	result, err := json.Marshal(v)
+	if err != nil {
+		http.Error(r, err.Error(), 500)
+		return
+	}
+
+	r.Header().Set(ContentType, r.ctypes.jsonp)
+	r.WriteHeader(status)
+	r.Write([]byte(callback + "("))
+	r.Write(result)
+	r.Write([]byte(");"))
 }
 
 func (r *renderer) Error(status int) {
@@ -0,0 +1,53 @@
+package render
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/codegangsta/martini"
+)
+
+// Test_HTML_Assets compiles templates from AssetFn/AssetNames instead of the filesystem, as a
+// single-binary deploy would via go-bindata, and asserts the Directory path is never touched.
+func Test_HTML_Assets(t *testing.T) {
+	assets := map[string]string{
+		"templates/page.tmpl": "asset:{{.}}",
+	}
+
+	assetNames := func() []string {
+		names := make([]string, 0, len(assets))
+		for name := range assets {
+			names = append(names, name)
+		}
+		return names
+	}
+	assetFn := func(name string) ([]byte, error) {
+		body, ok := assets[name]
+		if !ok {
+			return nil, fmt.Errorf("asset %q not found", name)
+		}
+		return []byte(body), nil
+	}
+
+	m := martini.Classic()
+	m.Use(Renderer(RenderConfig{
+		Directory:  "templates",
+		Extension:  ".tmpl",
+		AssetFn:    assetFn,
+		AssetNames: assetNames,
+	}))
+	m.Get("/page", func(r Render) {
+		r.HTML(200, "page", "hello")
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/page", nil)
+	m.ServeHTTP(res, req)
+
+	want := "asset:hello"
+	if got := res.Body.String(); got != want {
+		t.Errorf("got body %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,52 @@
+package render
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/codegangsta/martini"
+)
+
+// Test_Charset asserts a configured Charset replaces the default UTF-8 suffix on the JSON, XML,
+// Text, and JSONP Content-Type headers.
+func Test_Charset(t *testing.T) {
+	m := martini.Classic()
+	m.Use(Renderer(RenderConfig{Directory: ".", Extension: ".tmpl", Charset: "ISO-8859-1"}))
+	m.Get("/json", func(r Render) { r.JSON(200, "hello") })
+	m.Get("/xml", func(r Render) { r.XML(200, "hello") })
+	m.Get("/text", func(r Render) { r.Text(200, "hello") })
+	m.Get("/jsonp", func(r Render) { r.JSONP(200, "cb", "hello") })
+
+	cases := map[string]string{
+		"/json":  "application/json; charset=ISO-8859-1",
+		"/xml":   "text/xml; charset=ISO-8859-1",
+		"/text":  "text/plain; charset=ISO-8859-1",
+		"/jsonp": "application/javascript; charset=ISO-8859-1",
+	}
+	for path, want := range cases {
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", path, nil)
+		m.ServeHTTP(res, req)
+
+		if got := res.Header().Get(ContentType); got != want {
+			t.Errorf("%s: got Content-Type %q, want %q", path, got, want)
+		}
+	}
+}
+
+// Test_Charset_Default asserts Content-Type falls back to UTF-8 when Charset is left empty.
+func Test_Charset_Default(t *testing.T) {
+	m := martini.Classic()
+	m.Use(Renderer(RenderConfig{Directory: ".", Extension: ".tmpl"}))
+	m.Get("/json", func(r Render) { r.JSON(200, "hello") })
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/json", nil)
+	m.ServeHTTP(res, req)
+
+	want := "application/json; charset=UTF-8"
+	if got := res.Header().Get(ContentType); got != want {
+		t.Errorf("got Content-Type %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,78 @@
+package render
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/codegangsta/martini"
+)
+
+// Test_HTML_Amber compiles a .amber template through AmberEngine and asserts the data passed to
+// HTML is interpolated into the rendered output.
+func Test_HTML_Amber(t *testing.T) {
+	dir, err := ioutil.TempDir("", "render-amber-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "page.amber"), []byte("p Hello #{Name}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	m := martini.Classic()
+	m.Use(Renderer(RenderConfig{
+		Directory: dir,
+		Extension: AmberExtension,
+		Engines:   []TemplateEngine{&AmberEngine{}},
+	}))
+	m.Get("/page", func(r Render) {
+		r.HTML(200, "page", map[string]interface{}{"Name": "hello"})
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/page", nil)
+	m.ServeHTTP(res, req)
+
+	want := "<p>Hello hello</p>\n"
+	if got := res.Body.String(); got != want {
+		t.Errorf("got body %q, want %q", got, want)
+	}
+}
+
+// Test_HTML_Markdown compiles a .md template through MarkdownEngine and asserts the source is
+// first executed as a text/template against the HTML call's data, then converted to HTML.
+func Test_HTML_Markdown(t *testing.T) {
+	dir, err := ioutil.TempDir("", "render-markdown-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "page.md"), []byte("# Hello {{.}}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	m := martini.Classic()
+	m.Use(Renderer(RenderConfig{
+		Directory: dir,
+		Extension: MarkdownExtension,
+		Engines:   []TemplateEngine{&MarkdownEngine{}},
+	}))
+	m.Get("/page", func(r Render) {
+		r.HTML(200, "page", "world")
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/page", nil)
+	m.ServeHTTP(res, req)
+
+	want := "<h1>Hello world</h1>\n"
+	if got := res.Body.String(); got != want {
+		t.Errorf("got body %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,85 @@
+package render
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/codegangsta/martini"
+)
+
+// Test_JSON_IndentJSON asserts IndentJSON marshals with a two-space indent instead of the
+// compact form.
+func Test_JSON_IndentJSON(t *testing.T) {
+	m := martini.Classic()
+	m.Use(Renderer(RenderConfig{Directory: ".", Extension: ".tmpl", IndentJSON: true}))
+	m.Get("/json", func(r Render) {
+		r.JSON(200, map[string]string{"hello": "world"})
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/json", nil)
+	m.ServeHTTP(res, req)
+
+	want := "{\n  \"hello\": \"world\"\n}"
+	if got := res.Body.String(); got != want {
+		t.Errorf("got body %q, want %q", got, want)
+	}
+}
+
+// Test_JSON_Prefix asserts JSONPrefix is written before the marshaled payload, as a defense
+// against JSON hijacking in older browsers.
+func Test_JSON_Prefix(t *testing.T) {
+	m := martini.Classic()
+	m.Use(Renderer(RenderConfig{Directory: ".", Extension: ".tmpl", JSONPrefix: ")]}',\n"}))
+	m.Get("/json", func(r Render) {
+		r.JSON(200, map[string]string{"hello": "world"})
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/json", nil)
+	m.ServeHTTP(res, req)
+
+	want := ")]}',\n{\"hello\":\"world\"}"
+	if got := res.Body.String(); got != want {
+		t.Errorf("got body %q, want %q", got, want)
+	}
+}
+
+// Test_JSON_StreamJSON asserts StreamJSON encodes directly to the ResponseWriter via
+// json.Encoder, and that with HTMLEscape left false, <, >, and & are not escaped in the output.
+func Test_JSON_StreamJSON(t *testing.T) {
+	m := martini.Classic()
+	m.Use(Renderer(RenderConfig{Directory: ".", Extension: ".tmpl", StreamJSON: true}))
+	m.Get("/json", func(r Render) {
+		r.JSON(200, map[string]string{"html": "<b>"})
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/json", nil)
+	m.ServeHTTP(res, req)
+
+	want := "{\"html\":\"<b>\"}\n"
+	if got := res.Body.String(); got != want {
+		t.Errorf("got body %q, want %q", got, want)
+	}
+}
+
+// Test_JSON_StreamJSON_HTMLEscape asserts HTMLEscape, when enabled, escapes <, >, and & in the
+// StreamJSON encoder's output.
+func Test_JSON_StreamJSON_HTMLEscape(t *testing.T) {
+	m := martini.Classic()
+	m.Use(Renderer(RenderConfig{Directory: ".", Extension: ".tmpl", StreamJSON: true, HTMLEscape: true}))
+	m.Get("/json", func(r Render) {
+		r.JSON(200, map[string]string{"html": "<b>"})
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/json", nil)
+	m.ServeHTTP(res, req)
+
+	want := "{\"html\":\"\\u003cb\\u003e\"}\n"
+	if got := res.Body.String(); got != want {
+		t.Errorf("got body %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,152 @@
+package render
+
+import (
+	"html/template"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/codegangsta/martini"
+)
+
+func writeLayoutFixture(t *testing.T, dir string) {
+	files := map[string]string{
+		"layout.tmpl":          "Header|{{yield}}|{{partial \"footer\"}}|current={{current}}",
+		"page.tmpl":            "Page:{{.}}",
+		"partials/footer.tmpl": "Footer",
+	}
+	for name, body := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(body), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// Test_HTML_Layout renders a page through a layout that uses {{yield}}, {{partial}}, and
+// {{current}}, and asserts the layout wraps the page's own output and the partial's output.
+func Test_HTML_Layout(t *testing.T) {
+	dir, err := ioutil.TempDir("", "render-layout-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	writeLayoutFixture(t, dir)
+
+	m := martini.Classic()
+	m.Use(Renderer(RenderConfig{Directory: dir, Extension: ".tmpl", Layout: "layout"}))
+	m.Get("/page", func(r Render) {
+		r.HTML(200, "page", "hello")
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/page", nil)
+	m.ServeHTTP(res, req)
+
+	want := "Header|Page:hello|Footer|current=page"
+	if got := res.Body.String(); got != want {
+		t.Errorf("got body %q, want %q", got, want)
+	}
+}
+
+// Test_HTML_LayoutNotRenderablePage asserts that the layout itself can no longer be requested as
+// an ordinary page: doing so used to feed {{yield}} back into the layout and recurse until the
+// goroutine's stack overflowed, crashing the whole process.
+func Test_HTML_LayoutNotRenderablePage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "render-layout-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	writeLayoutFixture(t, dir)
+
+	m := martini.Classic()
+	m.Use(Renderer(RenderConfig{Directory: dir, Extension: ".tmpl", Layout: "layout"}))
+	m.Get("/layout", func(r Render) {
+		r.HTML(200, "layout", nil)
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/layout", nil)
+	m.ServeHTTP(res, req)
+
+	if res.Code != http.StatusInternalServerError {
+		t.Errorf("got status %d, want %d", res.Code, http.StatusInternalServerError)
+	}
+}
+
+// Test_HTML_Funcs asserts a custom func registered via RenderConfig.Funcs is merged alongside the
+// built-in yield/partial/current helpers and callable from a compiled template.
+func Test_HTML_Funcs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "render-funcs-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "page.tmpl"), []byte("{{shout .}}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	funcs := template.FuncMap{
+		"shout": func(s string) string { return strings.ToUpper(s) + "!" },
+	}
+
+	m := martini.Classic()
+	m.Use(Renderer(RenderConfig{Directory: dir, Extension: ".tmpl", Funcs: funcs}))
+	m.Get("/page", func(r Render) {
+		r.HTML(200, "page", "hello")
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/page", nil)
+	m.ServeHTTP(res, req)
+
+	want := "HELLO!"
+	if got := res.Body.String(); got != want {
+		t.Errorf("got body %q, want %q", got, want)
+	}
+}
+
+// Test_HTML_PageCannotYield asserts that a non-layout template calling {{yield}} itself fails
+// with an error rather than recursing into itself forever: the layout's {{yield}} implementation
+// is bound on the whole cloned template tree, so without a guard any page (or partial) containing
+// {{yield}} would feed back into itself until the goroutine's stack overflowed.
+func Test_HTML_PageCannotYield(t *testing.T) {
+	dir, err := ioutil.TempDir("", "render-layout-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"layout.tmpl": "L:{{yield}}",
+		"page.tmpl":   "P:{{yield}}",
+	}
+	for name, body := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(body), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m := martini.Classic()
+	m.Use(Renderer(RenderConfig{Directory: dir, Extension: ".tmpl", Layout: "layout"}))
+	m.Get("/page", func(r Render) {
+		r.HTML(200, "page", nil)
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/page", nil)
+	m.ServeHTTP(res, req)
+
+	if res.Code != http.StatusInternalServerError {
+		t.Errorf("got status %d, want %d", res.Code, http.StatusInternalServerError)
+	}
+}
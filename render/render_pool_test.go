@@ -0,0 +1,55 @@
+package render
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/codegangsta/martini"
+)
+
+// Test_HTML_ConcurrentSafe fires many requests at a single shared Renderer at once, each asking
+// for a distinct value, and asserts each response carries its own value: every request renders
+// into its own pooled buffer and its own tree.Clone(), so one request's in-flight render must not
+// leak into another's output.
+func Test_HTML_ConcurrentSafe(t *testing.T) {
+	dir, err := ioutil.TempDir("", "render-pool-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "echo.tmpl"), []byte("value={{.}}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	m := martini.Classic()
+	m.Use(Renderer(RenderConfig{Directory: dir, Extension: ".tmpl"}))
+	m.Get("/echo/:n", func(r Render, params martini.Params) {
+		r.HTML(200, "echo", params["n"])
+	})
+
+	const n = 50
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func(i int) {
+			defer wg.Done()
+
+			res := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", fmt.Sprintf("/echo/%d", i), nil)
+			m.ServeHTTP(res, req)
+
+			want := fmt.Sprintf("value=%d", i)
+			if got := res.Body.String(); got != want {
+				t.Errorf("request %d: got body %q, want %q", i, got, want)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
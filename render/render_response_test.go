@@ -0,0 +1,115 @@
+package render
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/codegangsta/martini"
+)
+
+// Test_XML asserts XML marshals v and sets a charset-qualified text/xml Content-Type.
+func Test_XML(t *testing.T) {
+	type payload struct {
+		Value string `xml:"value"`
+	}
+
+	m := martini.Classic()
+	m.Use(Renderer(RenderConfig{Directory: ".", Extension: ".tmpl"}))
+	m.Get("/xml", func(r Render) {
+		r.XML(200, payload{Value: "hello"})
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/xml", nil)
+	m.ServeHTTP(res, req)
+
+	if ct := res.Header().Get(ContentType); ct != "text/xml; charset=UTF-8" {
+		t.Errorf("got Content-Type %q, want %q", ct, "text/xml; charset=UTF-8")
+	}
+	want := "<payload><value>hello</value></payload>"
+	if got := res.Body.String(); got != want {
+		t.Errorf("got body %q, want %q", got, want)
+	}
+}
+
+// Test_Text asserts Text writes the given status and string with a text/plain Content-Type.
+func Test_Text(t *testing.T) {
+	m := martini.Classic()
+	m.Use(Renderer(RenderConfig{Directory: ".", Extension: ".tmpl"}))
+	m.Get("/text", func(r Render) {
+		r.Text(201, "hello world")
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/text", nil)
+	m.ServeHTTP(res, req)
+
+	if res.Code != 201 {
+		t.Errorf("got status %d, want %d", res.Code, 201)
+	}
+	if ct := res.Header().Get(ContentType); ct != "text/plain; charset=UTF-8" {
+		t.Errorf("got Content-Type %q, want %q", ct, "text/plain; charset=UTF-8")
+	}
+	if got := res.Body.String(); got != "hello world" {
+		t.Errorf("got body %q, want %q", got, "hello world")
+	}
+}
+
+// Test_Data asserts Data writes raw bytes with application/octet-stream and no charset suffix.
+func Test_Data(t *testing.T) {
+	m := martini.Classic()
+	m.Use(Renderer(RenderConfig{Directory: ".", Extension: ".tmpl"}))
+	m.Get("/data", func(r Render) {
+		r.Data(200, []byte{0x01, 0x02, 0x03})
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/data", nil)
+	m.ServeHTTP(res, req)
+
+	if ct := res.Header().Get(ContentType); ct != ContentBinary {
+		t.Errorf("got Content-Type %q, want %q", ct, ContentBinary)
+	}
+	want := []byte{0x01, 0x02, 0x03}
+	if got := res.Body.Bytes(); string(got) != string(want) {
+		t.Errorf("got body %v, want %v", got, want)
+	}
+}
+
+// Test_JSONP asserts JSONP wraps the marshaled value in the named callback.
+func Test_JSONP(t *testing.T) {
+	m := martini.Classic()
+	m.Use(Renderer(RenderConfig{Directory: ".", Extension: ".tmpl"}))
+	m.Get("/jsonp", func(r Render) {
+		r.JSONP(200, "handleResult", map[string]string{"hello": "world"})
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/jsonp", nil)
+	m.ServeHTTP(res, req)
+
+	want := `handleResult({"hello":"world"});`
+	if got := res.Body.String(); got != want {
+		t.Errorf("got body %q, want %q", got, want)
+	}
+}
+
+// Test_JSONP_RejectsInvalidCallback asserts a callback name containing characters that could
+// break out of the application/javascript response (e.g. "</script>") is rejected with a 400
+// instead of being reflected back verbatim.
+func Test_JSONP_RejectsInvalidCallback(t *testing.T) {
+	m := martini.Classic()
+	m.Use(Renderer(RenderConfig{Directory: ".", Extension: ".tmpl"}))
+	m.Get("/jsonp", func(r Render) {
+		r.JSONP(200, "a</script><script>alert(1)", map[string]string{"hello": "world"})
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/jsonp", nil)
+	m.ServeHTTP(res, req)
+
+	if res.Code != http.StatusBadRequest {
+		t.Errorf("got status %d, want %d", res.Code, http.StatusBadRequest)
+	}
+}